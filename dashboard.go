@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// dashboard renders a live-updating view of the entries a tailer is
+// accumulating: a table of the top offending IPs, a per-minute attempt
+// rate, and a geo summary
+type dashboard struct {
+	tailer *tailer
+	app    *tview.Application
+	table  *tview.Table
+	rate   *tview.TextView
+	geo    *tview.TextView
+}
+
+func newDashboard(t *tailer) *dashboard {
+	d := &dashboard{
+		tailer: t,
+		app:    tview.NewApplication(),
+		table:  tview.NewTable().SetBorders(false),
+		rate:   tview.NewTextView().SetDynamicColors(true),
+		geo:    tview.NewTextView().SetDynamicColors(true),
+	}
+
+	flex := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(d.table, 0, 3, false).
+		AddItem(d.rate, 1, 0, false).
+		AddItem(d.geo, 0, 1, false)
+
+	d.app.SetRoot(flex, true)
+	d.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc || event.Rune() == 'q' {
+			d.app.Stop()
+			return nil
+		}
+		return event
+	})
+
+	return d
+}
+
+// run consumes deltas from the tailer and redraws the dashboard until the
+// user quits; it blocks for the lifetime of the TUI
+func (d *dashboard) run(deltas <-chan entryDelta) {
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case delta, ok := <-deltas:
+				if !ok {
+					return
+				}
+				d.onDelta(delta)
+			case <-ticker.C:
+				d.app.QueueUpdateDraw(d.redraw)
+			}
+		}
+	}()
+
+	if err := d.app.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// onDelta just triggers a redraw; the attempt rate itself is tracked by the
+// tailer against actual ingested lines, since resolveLocation publishes a
+// second, asynchronous delta per newly-seen IP that isn't a new attempt
+func (d *dashboard) onDelta(delta entryDelta) {
+	d.app.QueueUpdateDraw(d.redraw)
+}
+
+func (d *dashboard) redraw() {
+	entries := flattenEntries(d.tailer.snapshot())
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Count > entries[j].Count })
+
+	d.table.Clear()
+	d.table.SetCell(0, 0, tview.NewTableCell("IP").SetSelectable(false))
+	d.table.SetCell(0, 1, tview.NewTableCell("Count").SetSelectable(false))
+	d.table.SetCell(0, 2, tview.NewTableCell("Country").SetSelectable(false))
+
+	max := 10
+	if len(entries) < max {
+		max = len(entries)
+	}
+	for i := 0; i < max; i++ {
+		ae := entries[i]
+		d.table.SetCell(i+1, 0, tview.NewTableCell(ae.IP))
+		d.table.SetCell(i+1, 1, tview.NewTableCell(fmt.Sprintf("%d", ae.Count)))
+		d.table.SetCell(i+1, 2, tview.NewTableCell(ae.Country))
+	}
+
+	fmt.Fprintf(d.rate.Clear(), "attempts/min: %d", d.tailer.attemptRate())
+	fmt.Fprintf(d.geo.Clear(), "%s", composeGeoSummary(entries))
+}
+
+func flattenEntries(dae []datedAuthEntries) []authEntry {
+	var entries []authEntry
+	for _, day := range dae {
+		entries = append(entries, day.Entries...)
+	}
+	return entries
+}
+
+func composeGeoSummary(entries []authEntry) string {
+	counts := map[string]int{}
+	for _, ae := range entries {
+		if ae.Country == "" {
+			continue
+		}
+		counts[ae.Country] += ae.Count
+	}
+
+	countries := make([]string, 0, len(counts))
+	for country := range counts {
+		countries = append(countries, country)
+	}
+	sort.Slice(countries, func(i, j int) bool { return counts[countries[i]] > counts[countries[j]] })
+
+	summary := "by country: "
+	for i, country := range countries {
+		if i > 0 {
+			summary += ", "
+		}
+		summary += fmt.Sprintf("%s=%d", country, counts[country])
+	}
+	return summary
+}
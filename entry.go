@@ -61,29 +61,74 @@ func (ae allEntries) print() {
 		fmt.Println("Date: " + dae.Date)
 		color.Unset()
 		for _, ae := range dae.Entries {
-			if ae.Count >= threshold {
-				color.Set(color.FgBlue, color.Bold)
-				fmt.Printf("IP: %s\n", ae.IP)
-				color.Unset()
-				color.Set(color.FgYellow)
-				fmt.Print("Location: ")
-				color.Unset()
-				fmt.Println(ae.composeLocationString())
-				color.Set(color.FgYellow)
-				fmt.Print("Attempts: ")
-				color.Unset()
-				fmt.Println(ae.Count)
-				color.Set(color.FgYellow)
-				fmt.Print("Usernames: ")
-				color.Unset()
-				fmt.Println(strings.Join(ae.Users, ", "))
+			if ae.Count < threshold {
+				continue
 			}
+			if len(fields) > 0 {
+				fmt.Println(fieldString(ae, fields))
+				continue
+			}
+			color.Set(color.FgBlue, color.Bold)
+			fmt.Printf("IP: %s\n", ae.IP)
+			color.Unset()
+			color.Set(color.FgYellow)
+			fmt.Print("Location: ")
+			color.Unset()
+			fmt.Println(ae.composeLocationString())
+			color.Set(color.FgYellow)
+			fmt.Print("Attempts: ")
+			color.Unset()
+			fmt.Println(ae.Count)
+			color.Set(color.FgYellow)
+			fmt.Print("Usernames: ")
+			color.Unset()
+			fmt.Println(strings.Join(ae.Users, ", "))
 		}
 		fmt.Println()
 	}
 }
 
+// paginatedOutput wraps the JSON-marshaled entries with the total/offset/
+// limit header consumers can use to page through large result sets; Entries
+// holds either the full allEntries or, when -fields is set, a narrowed
+// projection built from projectEntry
+type paginatedOutput struct {
+	Total   int         `json:"total"`
+	Offset  int         `json:"offset"`
+	Limit   int         `json:"limit"`
+	Entries interface{} `json:"entries"`
+}
+
 func (ae allEntries) jsonPrint() {
-	bytes, _ := json.MarshalIndent(ae, "", "    ")
+	out := paginatedOutput{
+		Total:   paginationTotal,
+		Offset:  offset,
+		Limit:   limit,
+		Entries: ae.projectedOrSelf(),
+	}
+	bytes, _ := json.MarshalIndent(out, "", "    ")
 	fmt.Println(string(bytes))
-}
\ No newline at end of file
+}
+
+// projectedOrSelf returns ae unchanged when -fields wasn't supplied, or a
+// []map[string]interface{} narrowed to the selected fields otherwise
+func (ae allEntries) projectedOrSelf() interface{} {
+	if len(fields) == 0 {
+		return ae
+	}
+
+	type projectedDate struct {
+		Date    string                   `json:"date"`
+		Entries []map[string]interface{} `json:"entries"`
+	}
+
+	projected := make([]projectedDate, len(ae))
+	for i, dae := range ae {
+		entries := make([]map[string]interface{}, len(dae.Entries))
+		for j, entry := range dae.Entries {
+			entries[j] = projectEntry(entry, fields)
+		}
+		projected[i] = projectedDate{Date: dae.Date, Entries: entries}
+	}
+	return projected
+}
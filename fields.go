@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// fieldAliases maps commonly-expected field names to the authEntry json tag
+// they actually correspond to, so -fields accepts the name users would
+// naturally reach for even where it doesn't match the struct tag verbatim
+// (ex. "users" for the Users field's "usernames" tag)
+var fieldAliases = map[string]string{
+	"users": "usernames",
+}
+
+// parseFields splits a -fields value on commas or semicolons, lower-casing
+// each name so lookups against authEntry's json tags are case-insensitive,
+// and resolving known aliases to their canonical json tag name
+func parseFields(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+
+	raw = strings.NewReplacer(";", ",").Replace(raw)
+	var out []string
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if alias, ok := fieldAliases[f]; ok {
+			f = alias
+		}
+		if f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// fieldIndex maps the lower-cased json tag of each authEntry field to its
+// struct field index, rebuilt via reflection over authEntry on every call
+func fieldIndex() map[string]int {
+	index := map[string]int{}
+	t := reflect.TypeOf(authEntry{})
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name != "" {
+			index[strings.ToLower(name)] = i
+		}
+	}
+	return index
+}
+
+// selectFields validates fields against authEntry's json tags and returns
+// only the recognized ones, logging the rest
+func selectFields(fields []string) []string {
+	index := fieldIndex()
+	var valid []string
+	for _, f := range fields {
+		if _, ok := index[f]; ok {
+			valid = append(valid, f)
+		} else {
+			debug("ignoring unknown -fields entry %q", f)
+		}
+	}
+	return valid
+}
+
+// projectEntry builds a map containing only the selected fields of ae,
+// keyed by their json tag name, for use in the JSON output path
+func projectEntry(ae authEntry, fields []string) map[string]interface{} {
+	index := fieldIndex()
+	v := reflect.ValueOf(ae)
+
+	out := map[string]interface{}{}
+	for _, f := range fields {
+		out[f] = v.Field(index[f]).Interface()
+	}
+	return out
+}
+
+// fieldString renders the selected fields of ae as a single plaintext line
+// ("name: value, name: value, ...") for the -fields plaintext output path
+func fieldString(ae authEntry, fields []string) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		val := projectEntry(ae, []string{f})[f]
+		if raw, err := json.Marshal(val); err == nil {
+			parts[i] = fmt.Sprintf("%s: %s", f, raw)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestParseFieldsAliasesUsersToUsernames(t *testing.T) {
+	got := parseFields("ip,country,count,users")
+	want := []string{"ip", "country", "count", "usernames"}
+
+	if len(got) != len(want) {
+		t.Fatalf("parseFields() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseFields()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSelectFieldsAcceptsAliasedUsers(t *testing.T) {
+	selected := selectFields(parseFields("users"))
+	if len(selected) != 1 || selected[0] != "usernames" {
+		t.Errorf("selectFields(parseFields(\"users\")) = %v, want [usernames]", selected)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var failedLoginRx = regexp.MustCompile(`^(\w+\s+\d+)\s+\S+\s+\S+\s+sshd\[\d+\]:\s+Failed password for (?:invalid user )?(\S+) from (\S+)`)
+
+// entryDelta is emitted whenever the streaming parser observes a new or
+// updated authEntry while tailing the log file
+type entryDelta struct {
+	Date  string
+	Entry authEntry
+}
+
+// tailer keeps a log file open in tail -f fashion, re-opening it when the
+// underlying inode changes (log rotation) and feeding newly-appended lines
+// to parseSSHAttemptLine for incremental parsing
+type tailer struct {
+	path    string
+	file    *os.File
+	reader  *bufio.Reader
+	ino     uint64
+	locator IPLocator
+
+	mu                 sync.Mutex
+	state              []datedAuthEntries
+	resolving          map[string]bool
+	attemptsThisMinute int
+	minuteStart        time.Time
+}
+
+// newTailer opens path in tail -f mode and builds the IPLocator the -follow
+// dashboard uses to fill in each entry's geo summary, mirroring how
+// applyEntryFilters resolves locations for one-shot mode
+func newTailer(path string) (*tailer, error) {
+	t := &tailer{path: path, resolving: map[string]bool{}, minuteStart: time.Now()}
+	if err := t.reopen(); err != nil {
+		return nil, err
+	}
+
+	locator, err := newIPLocator()
+	if err != nil {
+		return nil, err
+	}
+	t.locator = locator
+
+	return t, nil
+}
+
+func (t *tailer) reopen() error {
+	if t.file != nil {
+		t.file.Close()
+	}
+	file, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	t.file = file
+	t.reader = bufio.NewReader(file)
+	t.ino = inode(info)
+	return nil
+}
+
+// run polls the file for new lines and rotation, publishing a delta on out
+// for every authEntry that is created or updated; it blocks until stop is
+// closed
+func (t *tailer) run(out chan<- entryDelta, stop <-chan struct{}) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			t.poll(out)
+		}
+	}
+}
+
+func (t *tailer) poll(out chan<- entryDelta) {
+	if info, err := os.Stat(t.path); err == nil && inode(info) != t.ino {
+		debug("log rotation detected for %s, reopening", t.path)
+		if err := t.reopen(); err != nil {
+			debug("failed to reopen rotated log %s: %s", t.path, err.Error())
+			return
+		}
+	}
+
+	for {
+		line, err := t.reader.ReadString('\n')
+		if line != "" {
+			t.ingest(line, out)
+		}
+		if err != nil {
+			if err != io.EOF {
+				debug("error reading %s: %s", t.path, err.Error())
+			}
+			return
+		}
+	}
+}
+
+func (t *tailer) ingest(line string, out chan<- entryDelta) {
+	date, entry, ok := parseSSHAttemptLine(line)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	t.recordAttempt()
+	for idx := range t.state {
+		if t.state[idx].Date != date {
+			continue
+		}
+		if eidx, found := t.state[idx].exists(entry.IP); found {
+			for _, un := range entry.Users {
+				t.state[idx].Entries[eidx].addUser(un)
+			}
+			out <- entryDelta{Date: date, Entry: t.state[idx].Entries[eidx]}
+			t.mu.Unlock()
+			t.resolveLocation(entry.IP, out)
+			return
+		}
+		t.state[idx].Entries = append(t.state[idx].Entries, entry)
+		out <- entryDelta{Date: date, Entry: entry}
+		t.mu.Unlock()
+		t.resolveLocation(entry.IP, out)
+		return
+	}
+
+	t.state = append(t.state, datedAuthEntries{Date: date, Entries: []authEntry{entry}})
+	out <- entryDelta{Date: date, Entry: entry}
+	t.mu.Unlock()
+	t.resolveLocation(entry.IP, out)
+}
+
+// recordAttempt counts one ingested line toward the per-minute attempt
+// rate the dashboard displays; callers must hold t.mu
+func (t *tailer) recordAttempt() {
+	now := time.Now()
+	if now.Sub(t.minuteStart) >= time.Minute {
+		t.attemptsThisMinute = 0
+		t.minuteStart = now
+	}
+	t.attemptsThisMinute++
+}
+
+// attemptRate returns the number of lines ingested in the current one-
+// minute window, resetting to 0 once that window has elapsed
+func (t *tailer) attemptRate() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if time.Since(t.minuteStart) >= time.Minute {
+		return 0
+	}
+	return t.attemptsThisMinute
+}
+
+// resolveLocation looks up ip's location in the background and publishes a
+// follow-up delta once it resolves, so the dashboard's geo summary fills in
+// without blocking the line-polling loop on network round-trips; each IP is
+// only ever looked up once
+func (t *tailer) resolveLocation(ip string, out chan<- entryDelta) {
+	t.mu.Lock()
+	if t.locator == nil || t.resolving[ip] {
+		t.mu.Unlock()
+		return
+	}
+	t.resolving[ip] = true
+	t.mu.Unlock()
+
+	go func() {
+		loc, err := t.locator.locateIP(ip)
+		if err != nil {
+			debug("error getting location data for IP '%s': %s", ip, err.Error())
+			return
+		}
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for idx := range t.state {
+			eidx, found := t.state[idx].exists(ip)
+			if !found {
+				continue
+			}
+			ae := &t.state[idx].Entries[eidx]
+			ae.Country, ae.Region, ae.City, ae.Lat, ae.Long = loc.Country, loc.Region, loc.City, loc.Lat, loc.Long
+			out <- entryDelta{Date: t.state[idx].Date, Entry: *ae}
+		}
+	}()
+}
+
+// snapshot returns a copy of the entries accumulated so far, suitable for
+// rendering or filtering without holding the tailer's lock
+func (t *tailer) snapshot() []datedAuthEntries {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]datedAuthEntries, len(t.state))
+	for i, day := range t.state {
+		out[i] = datedAuthEntries{Date: day.Date, Entries: append([]authEntry(nil), day.Entries...)}
+	}
+	return out
+}
+
+// parseSSHAttemptLine applies the same matching rules as parseSSHAttempts
+// to a single line, returning the date and authEntry it represents; this
+// lets the tailer reuse the streaming path without re-scanning the whole
+// file on every new line
+func parseSSHAttemptLine(line string) (string, authEntry, bool) {
+	matches := failedLoginRx.FindStringSubmatch(line)
+	if matches == nil {
+		return "", authEntry{}, false
+	}
+
+	date, user, ip := matches[1], matches[2], matches[3]
+	entry := authEntry{IP: ip, Users: []string{user}, Count: 1}
+	return date, entry, true
+}
+
+// inode returns the platform-specific inode number for a file, used to
+// detect log rotation between polls
+func inode(info os.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return stat.Ino
+	}
+	return 0
+}
+
+func runFollow() {
+	t, err := newTailer(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	deltas := make(chan entryDelta, 256)
+	stop := make(chan struct{})
+	go t.run(deltas, stop)
+	defer close(stop)
+
+	dash := newDashboard(t)
+	dash.run(deltas)
+}
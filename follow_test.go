@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeLocator struct {
+	calls int
+}
+
+func (f *fakeLocator) locateIP(ip string) (location, error) {
+	f.calls++
+	return location{Country: "Wakanda"}, nil
+}
+
+func TestTailerResolvesLocationForNewEntries(t *testing.T) {
+	fake := &fakeLocator{}
+	tl := &tailer{resolving: map[string]bool{}, locator: fake}
+
+	out := make(chan entryDelta, 8)
+	tl.ingest("Jan  1 00:00:00 host sshd[123]: Failed password for invalid user admin from 9.9.9.9 port 22 ssh2", out)
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case delta := <-out:
+			if delta.Entry.Country == "Wakanda" {
+				return
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for tailer to resolve and publish a located entry")
+		}
+	}
+}
+
+func TestTailerAttemptRateCountsIngestedLinesNotDeltas(t *testing.T) {
+	fake := &fakeLocator{}
+	tl := &tailer{resolving: map[string]bool{}, locator: fake, minuteStart: time.Now()}
+
+	out := make(chan entryDelta, 8)
+	tl.ingest("Jan  1 00:00:00 host sshd[123]: Failed password for invalid user admin from 9.9.9.9 port 22 ssh2", out)
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case delta := <-out:
+			if delta.Entry.Country == "Wakanda" {
+				goto done
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for tailer to resolve and publish a located entry")
+		}
+	}
+done:
+	if got := tl.attemptRate(); got != 1 {
+		t.Errorf("attemptRate() = %d, want 1 (one ingested line, even though resolveLocation published a second delta)", got)
+	}
+}
+
+func TestTailerResolveLocationOnlyCallsLocatorOnce(t *testing.T) {
+	fake := &fakeLocator{}
+	tl := &tailer{resolving: map[string]bool{}, locator: fake, state: []datedAuthEntries{
+		{Date: "Jan 1", Entries: []authEntry{{IP: "9.9.9.9"}}},
+	}}
+
+	out := make(chan entryDelta, 8)
+	tl.resolveLocation("9.9.9.9", out)
+	tl.resolveLocation("9.9.9.9", out)
+
+	time.Sleep(50 * time.Millisecond)
+	if fake.calls != 1 {
+		t.Errorf("locator called %d times, want 1", fake.calls)
+	}
+}
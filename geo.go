@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+// location holds the geographic data resolved for a single IP address
+type location struct {
+	Country string
+	Region  string
+	City    string
+	Lat     float64
+	Long    float64
+}
+
+// IPLocator resolves an IP address to a location; implementations may hit a
+// remote API, read a local database, or combine several strategies
+type IPLocator interface {
+	locateIP(ip string) (location, error)
+}
+
+// newIPLocator builds the IPLocator selected by -geo-backend, falling back
+// to the ip-api.com HTTP client when no backend is specified, and wraps the
+// result in the on-disk cache, returning it uncached only when the cache
+// can't be opened
+func newIPLocator() (IPLocator, error) {
+	var locator IPLocator
+	var err error
+
+	switch geoBackend {
+	case "", "ipapi":
+		locator = newIPAPIClient("http://ip-api.com/")
+	case "mmdb":
+		locator, err = newMMDBLocator(geoDB)
+	case "chain":
+		var mmdb *mmdbLocator
+		mmdb, err = newMMDBLocator(geoDB)
+		if err == nil {
+			locator = newChainLocator(mmdb, newIPAPIClient("http://ip-api.com/"))
+		}
+	default:
+		err = fmt.Errorf("unknown geo backend %q", geoBackend)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := newCachingLocator(defaultGeoCachePath(), geoCacheTTL, locator)
+	if err != nil {
+		debug("failed to open geolocation cache, proceeding uncached: %s", err.Error())
+		return locator, nil
+	}
+	if geoCacheClear {
+		if err := cached.clear(); err != nil {
+			debug("failed to clear geolocation cache: %s", err.Error())
+		}
+	}
+	return cached, nil
+}
+
+// closeIfCloser releases any resources an IPLocator built by newIPLocator
+// is holding open (namely the cachingLocator's BoltDB handle), without
+// requiring every IPLocator implementation to carry a close method
+func closeIfCloser(locator IPLocator) {
+	c, ok := locator.(interface{ close() error })
+	if !ok {
+		return
+	}
+	if err := c.close(); err != nil {
+		debug("error closing geolocation locator: %s", err.Error())
+	}
+}
+
+// mmdbLocator resolves IPs against a local MaxMind GeoLite2 City database,
+// avoiding the per-IP HTTP round-trip that newIPAPIClient requires
+type mmdbLocator struct {
+	db *geoip2.Reader
+}
+
+func newMMDBLocator(path string) (*mmdbLocator, error) {
+	if path == "" {
+		return nil, fmt.Errorf("-geo-db is required when -geo-backend=mmdb")
+	}
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening GeoLite2 database %q: %w", path, err)
+	}
+	return &mmdbLocator{db: db}, nil
+}
+
+func (m *mmdbLocator) locateIP(ip string) (location, error) {
+	record, err := m.db.City(net.ParseIP(ip))
+	if err != nil {
+		return location{}, err
+	}
+
+	return location{
+		Country: record.Country.Names["en"],
+		Region:  firstSubdivision(record),
+		City:    record.City.Names["en"],
+		Lat:     record.Location.Latitude,
+		Long:    record.Location.Longitude,
+	}, nil
+}
+
+func firstSubdivision(record *geoip2.City) string {
+	if len(record.Subdivisions) == 0 {
+		return ""
+	}
+	return record.Subdivisions[0].Names["en"]
+}
+
+// chainLocator tries each locator in order, returning the first successful
+// result; used to prefer the offline mmdb lookup but fall back to ip-api.com
+// for entries the local database can't resolve
+type chainLocator struct {
+	locators []IPLocator
+}
+
+func newChainLocator(locators ...IPLocator) *chainLocator {
+	return &chainLocator{locators: locators}
+}
+
+func (c *chainLocator) locateIP(ip string) (location, error) {
+	var lastErr error
+	for _, locator := range c.locators {
+		loc, err := locator.locateIP(ip)
+		if err == nil {
+			return loc, nil
+		}
+		lastErr = err
+	}
+	return location{}, lastErr
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+type erroringLocator struct{}
+
+func (erroringLocator) locateIP(ip string) (location, error) {
+	return location{}, errors.New("boom")
+}
+
+type fixedLocator struct {
+	loc location
+}
+
+func (f fixedLocator) locateIP(ip string) (location, error) {
+	return f.loc, nil
+}
+
+func TestChainLocatorFallsBackToNextLocator(t *testing.T) {
+	chain := newChainLocator(erroringLocator{}, fixedLocator{loc: location{Country: "Fallbackland"}})
+
+	loc, err := chain.locateIP("1.2.3.4")
+	if err != nil {
+		t.Fatalf("locateIP() error = %v", err)
+	}
+	if loc.Country != "Fallbackland" {
+		t.Errorf("Country = %q, want Fallbackland", loc.Country)
+	}
+}
+
+func TestChainLocatorReturnsLastErrorWhenAllFail(t *testing.T) {
+	chain := newChainLocator(erroringLocator{}, erroringLocator{})
+
+	if _, err := chain.locateIP("1.2.3.4"); err == nil {
+		t.Fatal("expected an error when every locator in the chain fails")
+	}
+}
+
+func TestNewIPLocatorRejectsUnknownBackend(t *testing.T) {
+	orig := geoBackend
+	geoBackend = "carrier-pigeon"
+	defer func() { geoBackend = orig }()
+
+	if _, err := newIPLocator(); err == nil {
+		t.Error("expected an error for an unknown -geo-backend value")
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var geoCacheBucket = []byte("locations")
+
+// maxCacheEntries bounds the on-disk cache's size; once exceeded, the
+// least-recently-accessed entries are evicted until the cache is back
+// under the cap
+var maxCacheEntries = 50000
+
+// cacheEntry is the on-disk representation of a cached lookup; a failed
+// lookup is stored with Failed set so it isn't retried until it expires
+type cacheEntry struct {
+	Location   location  `json:"location"`
+	Failed     bool      `json:"failed"`
+	StoredAt   time.Time `json:"stored_at"`
+	LastAccess time.Time `json:"last_access"`
+}
+
+// cachingLocator wraps another IPLocator with a persistent on-disk cache,
+// so repeated invocations of shar against the same auth log don't re-hit
+// the backing locator (and, for the ip-api.com client, its rate limit) for
+// IPs that were already resolved
+type cachingLocator struct {
+	db       *bolt.DB
+	ttl      time.Duration
+	fallback IPLocator
+}
+
+// newCachingLocator opens (creating if necessary) the BoltDB cache at path,
+// wrapping fallback so lookups are only delegated on a cache miss or
+// expired entry
+func newCachingLocator(path string, ttl time.Duration, fallback IPLocator) (*cachingLocator, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(geoCacheBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &cachingLocator{db: db, ttl: ttl, fallback: fallback}, nil
+}
+
+func (c *cachingLocator) locateIP(ip string) (location, error) {
+	if entry, ok := c.get(ip); ok {
+		if entry.Failed {
+			return location{}, fmt.Errorf("cached negative lookup for %s", ip)
+		}
+		return entry.Location, nil
+	}
+
+	loc, err := c.fallback.locateIP(ip)
+	now := time.Now()
+	entry := cacheEntry{Location: loc, Failed: err != nil, StoredAt: now, LastAccess: now}
+	if putErr := c.put(ip, entry); putErr != nil {
+		debug("failed to cache geolocation for %s: %s", ip, putErr.Error())
+	}
+	return loc, err
+}
+
+func (c *cachingLocator) get(ip string) (cacheEntry, bool) {
+	var entry cacheEntry
+	var found bool
+
+	_ = c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(geoCacheBucket)
+		raw := bucket.Get([]byte(ip))
+		if raw == nil {
+			return nil
+		}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil
+		}
+		if c.ttl > 0 && time.Since(entry.StoredAt) > c.ttl {
+			return nil
+		}
+		found = true
+
+		// touch the entry's LastAccess so evictLRU treats it as fresh
+		entry.LastAccess = time.Now()
+		if raw, err := json.Marshal(entry); err == nil {
+			_ = bucket.Put([]byte(ip), raw)
+		}
+		return nil
+	})
+
+	return entry, found
+}
+
+func (c *cachingLocator) put(ip string, entry cacheEntry) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(geoCacheBucket).Put([]byte(ip), raw)
+	}); err != nil {
+		return err
+	}
+
+	return c.evictLRU()
+}
+
+// evictLRU removes the least-recently-accessed entries once the cache
+// exceeds maxCacheEntries, so an unbounded stream of unique attacker IPs
+// can't grow the on-disk cache forever
+func (c *cachingLocator) evictLRU() error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(geoCacheBucket)
+		count := bucket.Stats().KeyN
+		if count <= maxCacheEntries {
+			return nil
+		}
+
+		type keyAccess struct {
+			key        []byte
+			lastAccess time.Time
+		}
+		entries := make([]keyAccess, 0, count)
+
+		err := bucket.ForEach(func(k, v []byte) error {
+			var entry cacheEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return nil
+			}
+			entries = append(entries, keyAccess{key: append([]byte{}, k...), lastAccess: entry.LastAccess})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		sort.Slice(entries, func(i, j int) bool { return entries[i].lastAccess.Before(entries[j].lastAccess) })
+
+		for _, e := range entries[:count-maxCacheEntries] {
+			if err := bucket.Delete(e.key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (c *cachingLocator) clear() error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(geoCacheBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(geoCacheBucket)
+		return err
+	})
+}
+
+func (c *cachingLocator) close() error {
+	return c.db.Close()
+}
+
+// defaultGeoCachePath returns ~/.cache/shar/geo.db, creating no directories
+// itself; newCachingLocator is responsible for that
+func defaultGeoCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".cache", "shar", "geo.db")
+	}
+	return filepath.Join(home, ".cache", "shar", "geo.db")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type stubLocator struct {
+	calls int
+}
+
+func (s *stubLocator) locateIP(ip string) (location, error) {
+	s.calls++
+	return location{Country: "Testlandia"}, nil
+}
+
+func TestCachingLocatorCachesLookups(t *testing.T) {
+	stub := &stubLocator{}
+	cache, err := newCachingLocator(filepath.Join(t.TempDir(), "geo.db"), time.Hour, stub)
+	if err != nil {
+		t.Fatalf("newCachingLocator() error = %v", err)
+	}
+	defer cache.close()
+
+	for i := 0; i < 3; i++ {
+		loc, err := cache.locateIP("1.2.3.4")
+		if err != nil {
+			t.Fatalf("locateIP() error = %v", err)
+		}
+		if loc.Country != "Testlandia" {
+			t.Errorf("Country = %q, want Testlandia", loc.Country)
+		}
+	}
+
+	if stub.calls != 1 {
+		t.Errorf("fallback called %d times, want 1 (subsequent lookups should hit the cache)", stub.calls)
+	}
+}
+
+func TestCachingLocatorEvictsDownToMaxCacheEntries(t *testing.T) {
+	origMax := maxCacheEntries
+	maxCacheEntries = 50
+	defer func() { maxCacheEntries = origMax }()
+
+	stub := &stubLocator{}
+	cache, err := newCachingLocator(filepath.Join(t.TempDir(), "geo.db"), time.Hour, stub)
+	if err != nil {
+		t.Fatalf("newCachingLocator() error = %v", err)
+	}
+	defer cache.close()
+
+	for i := 0; i < maxCacheEntries+10; i++ {
+		if _, err := cache.locateIP(fmt.Sprintf("10.%d.%d.%d", i/65536, (i/256)%256, i%256)); err != nil {
+			t.Fatalf("locateIP() error = %v", err)
+		}
+	}
+
+	var count int
+	_ = cache.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(geoCacheBucket).Stats().KeyN
+		return nil
+	})
+
+	if count > maxCacheEntries {
+		t.Errorf("cache holds %d entries, want at most %d after eviction", count, maxCacheEntries)
+	}
+}
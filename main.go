@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bufio"
 	"flag"
+	"io"
 	"log"
 	"os"
 	"regexp"
@@ -13,14 +15,27 @@ import (
 )
 
 var (
-	debugOn   bool
-	filename  string
-	jsonOut   bool
-	threshold int
-	address   string
-	user      string
-	locale    string
-	date      string
+	debugOn       bool
+	filename      string
+	jsonOut       bool
+	threshold     int
+	address       string
+	user          string
+	locale        string
+	date          string
+	follow        bool
+	geoBackend    string
+	geoDB         string
+	geoCacheTTL   time.Duration
+	geoCacheClear bool
+	offset        int
+	limit         int
+	sortBy        string
+	sortDesc      bool
+	promAddr      string
+	fieldsRaw     string
+	fields        []string
+	format        string
 )
 
 const (
@@ -36,6 +51,18 @@ func init() {
 	flag.StringVar(&user, "u", "", "limits output to entries that are logging in as the specified user")
 	flag.StringVar(&locale, "l", "", "limits output to entries that match the specified location string")
 	flag.StringVar(&date, "d", "", "limits output to entries from the specified date (ex. Jan 1)")
+	flag.BoolVar(&follow, "follow", false, "tails the auth log and renders a live-updating TUI dashboard instead of one-shot output")
+	flag.StringVar(&geoBackend, "geo-backend", "ipapi", "geolocation backend to use: ipapi, mmdb, or chain")
+	flag.StringVar(&geoDB, "geo-db", "", "path to a MaxMind GeoLite2-City.mmdb file, required for the mmdb and chain backends")
+	flag.DurationVar(&geoCacheTTL, "geo-cache-ttl", 7*24*time.Hour, "how long a cached geolocation lookup remains valid")
+	flag.BoolVar(&geoCacheClear, "geo-cache-clear", false, "clears the on-disk geolocation cache before running")
+	flag.IntVar(&offset, "offset", 0, "skips the first n entries after filtering and sorting")
+	flag.IntVar(&limit, "limit", 0, "limits output to n entries after filtering and sorting (0 means no limit)")
+	flag.StringVar(&sortBy, "sort", "count", "orders entries before pagination: count, ip, user, or country")
+	flag.BoolVar(&sortDesc, "desc", false, "reverses the sort order set by -sort")
+	flag.StringVar(&promAddr, "prom-addr", "", "runs shar as a long-running Prometheus exporter listening on the given address (ex. :9187) instead of one-shot output")
+	flag.StringVar(&fieldsRaw, "fields", "", "restricts output to the given comma- or semicolon-separated authEntry fields (ex. ip,country,count,users)")
+	flag.StringVar(&format, "format", "auto", "log format to parse: auto, auth, secure, journal, or json")
 }
 
 func main() {
@@ -44,13 +71,32 @@ func main() {
 	//
 	// }
 	flag.Parse()
+	fields = selectFields(parseFields(fieldsRaw))
+	validatePaginationFlags()
 
-	file, err := os.Open(filename)
-	if err != nil {
-		log.Fatal(err)
+	if follow {
+		runFollow()
+		return
+	}
+
+	if promAddr != "" {
+		runPromExporter()
+		return
+	}
+
+	var reader io.Reader
+	if filename == "-" {
+		reader = os.Stdin
+		debug("reading log data from stdin")
+	} else {
+		file, err := os.Open(filename)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		reader = file
+		debug("auth file loaded: %s", filename)
 	}
-	defer file.Close()
-	debug("auth file loaded: %s", filename)
 
 	//spinnerCharSet := []string{"-", "\\", "|", "/"}
 	spin := spinner.New(generateSpinnerSet(), 250*time.Millisecond)
@@ -58,7 +104,12 @@ func main() {
 		spin.Start()
 	}
 
-	attempts := parseSSHAttempts(file)
+	buffered := bufio.NewReaderSize(reader, sniffPeekBytes)
+	parser, err := newAuthLogParser(format, buffered)
+	if err != nil {
+		log.Fatal(err)
+	}
+	attempts := parser.parse(buffered)
 	debug("finished parsing log file")
 
 	// output parsed data to debug
@@ -74,9 +125,18 @@ func main() {
 		}
 	}
 
-	applyEntryFilters(attempts)
+	iac, err := newIPLocator()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeIfCloser(iac)
+
+	applyEntryFilters(attempts, iac)
 	debug("filtered data: %+v", attempts)
 
+	applyPagination(attempts)
+	debug("paginated data: %+v", attempts)
+
 	spin.Stop()
 
 	if jsonOut {
@@ -103,7 +163,7 @@ func applyDateFilter(dae []datedAuthEntries) []datedAuthEntries {
 // filter the results for each date's entries based on the provided command-line flags; order of filtering is not
 // particularly important (generally, we try to apply the strictest filters first), however,
 // the location filter should be last in order to make the fewest requests possible to the IP-API
-func applyEntryFilters(dae []datedAuthEntries) {
+func applyEntryFilters(dae []datedAuthEntries, iac IPLocator) {
 	for idx := range dae {
 		// count filter
 		if threshold > 0 {
@@ -132,7 +192,6 @@ func applyEntryFilters(dae []datedAuthEntries) {
 			dae[idx].Entries = filtered
 		}
 		// get IP locations in order to apply location filter
-		iac := newIPAPIClient("http://ip-api.com/")
 		dae[idx].Entries = dae[idx].apply(func(ae authEntry) authEntry {
 			debug("making API request for IP '%s'", ae.IP)
 			location, err := iac.locateIP(ae.IP)
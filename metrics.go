@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	failedAttemptsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shar_ssh_failed_attempts_total",
+		Help: "Total failed SSH login attempts observed, by IP, user, and country.",
+	}, []string{"ip", "user", "country"})
+
+	uniqueAttackers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "shar_ssh_unique_attackers",
+		Help: "Number of distinct attacker IPs currently matching the configured filters.",
+	})
+
+	attemptsPerCountry = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "shar_ssh_attempts_per_country",
+		Help: "Failed SSH login attempts, by country, matching the configured filters.",
+	}, []string{"country"})
+)
+
+// runPromExporter turns shar into a long-running process that re-parses
+// filename on an interval and exposes the results as Prometheus metrics on
+// -prom-addr, applying the same -i/-u/-l filters as the one-shot mode; the
+// IPLocator (and, with it, the on-disk geo cache's BoltDB handle) is built
+// once up front and reused for the life of the process rather than
+// reopened on every scrape
+func runPromExporter() {
+	iac, err := newIPLocator()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeIfCloser(iac)
+
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		collectMetrics(iac)
+		for range ticker.C {
+			collectMetrics(iac)
+		}
+	}()
+
+	http.Handle("/metrics", promhttp.Handler())
+	log.Fatal(http.ListenAndServe(promAddr, nil))
+}
+
+func collectMetrics(iac IPLocator) {
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Printf("error opening %s for metrics collection: %s", filename, err.Error())
+		return
+	}
+	defer file.Close()
+
+	buffered := bufio.NewReaderSize(file, sniffPeekBytes)
+	parser, err := newAuthLogParser(format, buffered)
+	if err != nil {
+		log.Printf("error selecting log parser for metrics collection: %s", err.Error())
+		return
+	}
+	attempts := parser.parse(buffered)
+	applyEntryFilters(attempts, iac)
+
+	failedAttemptsTotal.Reset()
+	attemptsPerCountry.Reset()
+
+	seen := map[string]bool{}
+	countryTotals := map[string]float64{}
+
+	for _, day := range attempts {
+		for _, ae := range day.Entries {
+			// ae.Count is the entry's total across every user in ae.Users,
+			// not a per-user count, so split it evenly rather than adding
+			// the full total once per user
+			share := float64(ae.Count) / float64(len(ae.Users))
+			for _, un := range ae.Users {
+				failedAttemptsTotal.WithLabelValues(ae.IP, un, ae.Country).Add(share)
+			}
+			seen[ae.IP] = true
+			countryTotals[ae.Country] += float64(ae.Count)
+		}
+	}
+
+	uniqueAttackers.Set(float64(len(seen)))
+	for country, total := range countryTotals {
+		attemptsPerCountry.WithLabelValues(country).Set(total)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollectMetricsPopulatesCounters(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "auth.log")
+	line := "Jan  1 00:00:00 host sshd[123]: Failed password for invalid user admin from 9.9.9.9 port 22 ssh2\n"
+	if err := os.WriteFile(logPath, []byte(line), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	origFilename, origFormat := filename, format
+	filename, format = logPath, "auth"
+	defer func() { filename, format = origFilename, origFormat }()
+
+	collectMetrics(fixedLocator{loc: location{Country: "Testlandia"}})
+
+	got := testutil.ToFloat64(failedAttemptsTotal.WithLabelValues("9.9.9.9", "admin", "Testlandia"))
+	if got != 1 {
+		t.Errorf("shar_ssh_failed_attempts_total{ip=9.9.9.9,user=admin,country=Testlandia} = %v, want 1", got)
+	}
+
+	if got := testutil.ToFloat64(uniqueAttackers); got != 1 {
+		t.Errorf("shar_ssh_unique_attackers = %v, want 1", got)
+	}
+}
+
+func TestCollectMetricsSplitsCountAcrossUsers(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "auth.log")
+	lines := "Jan  1 00:00:00 host sshd[123]: Failed password for invalid user admin from 8.8.8.8 port 22 ssh2\n" +
+		"Jan  1 00:00:01 host sshd[124]: Failed password for invalid user root from 8.8.8.8 port 22 ssh2\n"
+	if err := os.WriteFile(logPath, []byte(lines), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	origFilename, origFormat := filename, format
+	filename, format = logPath, "auth"
+	defer func() { filename, format = origFilename, origFormat }()
+
+	collectMetrics(fixedLocator{loc: location{Country: "Testlandia"}})
+
+	adminCount := testutil.ToFloat64(failedAttemptsTotal.WithLabelValues("8.8.8.8", "admin", "Testlandia"))
+	rootCount := testutil.ToFloat64(failedAttemptsTotal.WithLabelValues("8.8.8.8", "root", "Testlandia"))
+	if adminCount+rootCount != 2 {
+		t.Errorf("admin + root counts = %v, want 2 (the entry's total, not 2 per user)", adminCount+rootCount)
+	}
+}
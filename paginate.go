@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"sort"
+)
+
+// sortEntries orders entries according to -sort and -desc before pagination
+// is applied; an unrecognized -sort value leaves the entries untouched
+func sortEntries(entries []authEntry) {
+	var less func(i, j int) bool
+
+	switch sortBy {
+	case "ip":
+		less = func(i, j int) bool { return entries[i].IP < entries[j].IP }
+	case "user":
+		less = func(i, j int) bool { return firstUser(entries[i]) < firstUser(entries[j]) }
+	case "country":
+		less = func(i, j int) bool { return entries[i].Country < entries[j].Country }
+	case "count", "":
+		// count sorts descending by default so the most-attempted IPs
+		// (the ones worth paging through first) lead the output; -desc
+		// reverses this, same as it reverses every other -sort key
+		less = func(i, j int) bool { return entries[i].Count > entries[j].Count }
+	default:
+		return
+	}
+
+	if sortDesc {
+		inner := less
+		less = func(i, j int) bool { return inner(j, i) }
+	}
+	sort.SliceStable(entries, less)
+}
+
+func firstUser(ae authEntry) string {
+	if len(ae.Users) == 0 {
+		return ""
+	}
+	return ae.Users[0]
+}
+
+// validatePaginationFlags clamps -offset and -limit to sane, non-negative
+// values; called once after flag.Parse so paginate never has to reason
+// about out-of-range input
+func validatePaginationFlags() {
+	if offset < 0 {
+		log.Printf("-offset %d is negative, treating it as 0", offset)
+		offset = 0
+	}
+	if limit < 0 {
+		log.Printf("-limit %d is negative, treating it as 0 (no limit)", limit)
+		limit = 0
+	}
+}
+
+// paginate slices entries down to the window selected by -offset and
+// -limit; a non-positive limit means "no limit". offset/limit are clamped
+// here too, rather than trusting validatePaginationFlags to have already
+// run, so this function can never panic on an out-of-range value.
+func paginate(entries []authEntry) []authEntry {
+	off := offset
+	if off < 0 {
+		off = 0
+	}
+	if off >= len(entries) {
+		return []authEntry{}
+	}
+	entries = entries[off:]
+
+	if limit > 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// paginationTotal is the number of entries present across all dates before
+// -offset/-limit were applied, surfaced in the JSON output header
+var paginationTotal int
+
+// applyPagination sorts and paginates each date's entries in place, per the
+// -sort, -desc, -offset, and -limit flags
+func applyPagination(dae []datedAuthEntries) {
+	for idx := range dae {
+		paginationTotal += len(dae[idx].Entries)
+		sortEntries(dae[idx].Entries)
+		dae[idx].Entries = paginate(dae[idx].Entries)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestValidatePaginationFlagsClampsNegatives(t *testing.T) {
+	origOffset, origLimit := offset, limit
+	defer func() { offset, limit = origOffset, origLimit }()
+
+	offset, limit = -5, -3
+	validatePaginationFlags()
+
+	if offset != 0 {
+		t.Errorf("offset = %d, want 0", offset)
+	}
+	if limit != 0 {
+		t.Errorf("limit = %d, want 0", limit)
+	}
+}
+
+func TestPaginateDoesNotPanicOnNegativeOffset(t *testing.T) {
+	origOffset := offset
+	defer func() { offset = origOffset }()
+
+	offset = -5
+	entries := []authEntry{{IP: "1.1.1.1"}, {IP: "2.2.2.2"}}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("paginate panicked on negative offset: %v", r)
+		}
+	}()
+	paginate(entries)
+}
+
+func TestSortEntriesDefaultsCountDescending(t *testing.T) {
+	origSortBy, origDesc := sortBy, sortDesc
+	defer func() { sortBy, sortDesc = origSortBy, origDesc }()
+
+	sortBy, sortDesc = "count", false
+	entries := []authEntry{
+		{IP: "1.1.1.1", Count: 1},
+		{IP: "2.2.2.2", Count: 9},
+		{IP: "3.3.3.3", Count: 5},
+	}
+
+	sortEntries(entries)
+
+	if entries[0].Count != 9 || entries[len(entries)-1].Count != 1 {
+		t.Errorf("entries not sorted most-attempted-first: %+v", entries)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sniffPeekBytes is how far sniffFormat looks into the stream to guess its
+// format; callers must construct their *bufio.Reader with at least this
+// much buffer space, since Peek can never return more than the reader's
+// buffer size
+const sniffPeekBytes = 16384
+
+// journalMessageRx matches the sshd failure text inside a journald MESSAGE
+// field, which (unlike a raw auth.log line) carries no leading
+// "Jan  1 00:00:00 host sshd[123]: " prefix — that data lives in separate
+// journald JSON fields instead
+var journalMessageRx = regexp.MustCompile(`Failed password for (?:invalid user )?(\S+) from (\S+)`)
+
+// AuthLogParser turns a raw log stream into the dated entries shar reports
+// on; parseSSHAttempts remains the default Debian auth.log implementation,
+// and new formats can be added here without main needing to know about them.
+// It returns allEntries, not the bare []datedAuthEntries, so callers keep
+// access to allEntries's print/jsonPrint methods
+type AuthLogParser interface {
+	parse(r io.Reader) allEntries
+}
+
+// authLogParserFunc adapts a plain function to the AuthLogParser interface
+type authLogParserFunc func(r io.Reader) allEntries
+
+func (f authLogParserFunc) parse(r io.Reader) allEntries {
+	return f(r)
+}
+
+// newAuthLogParser selects the AuthLogParser for -format, sniffing the
+// first few lines of r when -format=auto
+func newAuthLogParser(format string, r *bufio.Reader) (AuthLogParser, error) {
+	if format == "auto" || format == "" {
+		format = sniffFormat(r)
+		debug("auto-detected log format: %s", format)
+	}
+
+	switch format {
+	case "auth":
+		return authLogParserFunc(func(r io.Reader) allEntries {
+			return parseSSHAttempts(r)
+		}), nil
+	case "secure":
+		return authLogParserFunc(parseRHELSecureLog), nil
+	case "journal":
+		return authLogParserFunc(parseJournalJSON), nil
+	case "json":
+		return authLogParserFunc(parseStructuredJSON), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// sniffFormat peeks at the first ~50 lines of r to guess its format,
+// without consuming them from the underlying reader
+func sniffFormat(r *bufio.Reader) string {
+	peeked, err := r.Peek(sniffPeekBytes)
+	if err != nil && len(peeked) == 0 {
+		return "auth"
+	}
+
+	lines := strings.Split(string(peeked), "\n")
+	if len(lines) > 50 {
+		lines = lines[:50]
+	}
+
+	jsonLines := 0
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "__REALTIME_TIMESTAMP") || strings.Contains(line, "\"MESSAGE\"") {
+			return "journal"
+		}
+		if strings.HasPrefix(line, "{") && json.Valid([]byte(line)) {
+			jsonLines++
+		}
+	}
+	if jsonLines > 0 {
+		return "json"
+	}
+
+	for _, line := range lines {
+		if strings.Contains(line, "sshd[") {
+			return "auth"
+		}
+	}
+	return "secure"
+}
+
+// journalEntry models the subset of `journalctl -u ssh -o json` fields shar
+// needs to extract a failed login attempt
+type journalEntry struct {
+	Message           string `json:"MESSAGE"`
+	RealtimeTimestamp string `json:"__REALTIME_TIMESTAMP"`
+	SyslogIdentifier  string `json:"SYSLOG_IDENTIFIER"`
+}
+
+// parseJournalJSON reads newline-delimited `journalctl -o json` records,
+// matching journalMessageRx against each record's MESSAGE field and deriving
+// the date from __REALTIME_TIMESTAMP rather than the message text, which
+// carries no timestamp of its own
+func parseJournalJSON(r io.Reader) allEntries {
+	var dae allEntries
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			debug("skipping unparseable journal line: %s", err.Error())
+			continue
+		}
+		addJournalMessage(&dae, entry)
+	}
+	return dae
+}
+
+// structuredLogEntry models a single structured sshd log record as emitted
+// by JSON-aware syslog shippers
+type structuredLogEntry struct {
+	Timestamp string `json:"timestamp"`
+	Message   string `json:"message"`
+	User      string `json:"user"`
+	IP        string `json:"ip"`
+}
+
+// parseStructuredJSON reads newline-delimited JSON sshd log records that
+// already carry structured user/ip fields, skipping the regex entirely
+func parseStructuredJSON(r io.Reader) allEntries {
+	var dae allEntries
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var entry structuredLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			debug("skipping unparseable structured log line: %s", err.Error())
+			continue
+		}
+		if entry.IP == "" {
+			continue
+		}
+		date := entry.Timestamp
+		if idx := strings.IndexByte(date, 'T'); idx > 0 {
+			date = date[:idx]
+		}
+		addEntry(&dae, date, authEntry{IP: entry.IP, Users: []string{entry.User}, Count: 1})
+	}
+	return dae
+}
+
+// parseRHELSecureLog parses RHEL/CentOS-style /var/log/secure, which shares
+// its sshd message format with Debian's auth.log
+func parseRHELSecureLog(r io.Reader) allEntries {
+	var dae allEntries
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		date, entry, ok := parseSSHAttemptLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		addEntry(&dae, date, entry)
+	}
+	return dae
+}
+
+// addJournalMessage extracts a failed-login authEntry from a journald
+// record's MESSAGE field and files it under the date derived from
+// __REALTIME_TIMESTAMP
+func addJournalMessage(dae *allEntries, entry journalEntry) {
+	matches := journalMessageRx.FindStringSubmatch(entry.Message)
+	if matches == nil {
+		return
+	}
+
+	date, err := journalDate(entry.RealtimeTimestamp)
+	if err != nil {
+		debug("skipping journal line with unparseable timestamp %q: %s", entry.RealtimeTimestamp, err.Error())
+		return
+	}
+
+	user, ip := matches[1], matches[2]
+	addEntry(dae, date, authEntry{IP: ip, Users: []string{user}, Count: 1})
+}
+
+// journalDate converts a journald __REALTIME_TIMESTAMP (microseconds since
+// the Unix epoch, as a decimal string) into the "Jan _2" date format used
+// elsewhere in datedAuthEntries
+func journalDate(realtimeTimestamp string) (string, error) {
+	micros, err := strconv.ParseInt(realtimeTimestamp, 10, 64)
+	if err != nil {
+		return "", err
+	}
+	return time.UnixMicro(micros).UTC().Format("Jan _2"), nil
+}
+
+// addEntry merges entry into dae under the given date, combining it with an
+// existing entry for the same IP rather than duplicating it
+func addEntry(dae *allEntries, date string, entry authEntry) {
+	for idx := range *dae {
+		if (*dae)[idx].Date != date {
+			continue
+		}
+		if eidx, found := (*dae)[idx].exists(entry.IP); found {
+			for _, un := range entry.Users {
+				(*dae)[idx].Entries[eidx].addUser(un)
+			}
+			return
+		}
+		(*dae)[idx].Entries = append((*dae)[idx].Entries, entry)
+		return
+	}
+	*dae = append(*dae, datedAuthEntries{Date: date, Entries: []authEntry{entry}})
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestParseJournalJSON(t *testing.T) {
+	input := `{"MESSAGE":"Failed password for invalid user admin from 1.2.3.4 port 51234 ssh2","__REALTIME_TIMESTAMP":"1617235200000000"}` + "\n"
+
+	dae := parseJournalJSON(strings.NewReader(input))
+	if len(dae) != 1 {
+		t.Fatalf("expected 1 date, got %d: %+v", len(dae), dae)
+	}
+	if len(dae[0].Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d: %+v", len(dae[0].Entries), dae[0].Entries)
+	}
+
+	entry := dae[0].Entries[0]
+	if entry.IP != "1.2.3.4" {
+		t.Errorf("IP = %q, want 1.2.3.4", entry.IP)
+	}
+	if len(entry.Users) != 1 || entry.Users[0] != "admin" {
+		t.Errorf("Users = %v, want [admin]", entry.Users)
+	}
+}
+
+func TestSniffFormatPeeksFullWindow(t *testing.T) {
+	padding := strings.Repeat("x", 10000)
+	input := padding + `{"MESSAGE":"hi","__REALTIME_TIMESTAMP":"1"}` + "\n"
+
+	r := bufio.NewReaderSize(strings.NewReader(input), sniffPeekBytes)
+	if got := sniffFormat(r); got != "journal" {
+		t.Errorf("sniffFormat() = %q, want %q (the journal marker sits past the first 4096 bytes)", got, "journal")
+	}
+}